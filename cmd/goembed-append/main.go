@@ -0,0 +1,89 @@
+// Command goembed-append appends a fresh zip of one or more files or
+// directories to a copy of a Go executable, for embeddedzip to read back at
+// runtime. Run with no -out flag to overwrite an existing embed on -exe in
+// place.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/interarticle/goembed/embeddedzip"
+	"github.com/interarticle/goembed/embeddedzip/appender"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "goembed-append:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	exePath := flag.String("exe", "", "path to the Go executable to embed into (required)")
+	outPath := flag.String("out", "", "path to write the resulting executable to (defaults to -exe, overwriting any existing embed in place)")
+	var arguments stringSliceFlag
+	flag.Var(&arguments, "arg", "argument to write to arguments.txt for embeddedargs.LoadEmbeddedArguments; repeatable")
+	flag.Parse()
+
+	if *exePath == "" {
+		return fmt.Errorf("-exe is required")
+	}
+	if *outPath == "" {
+		*outPath = *exePath
+	}
+	roots := flag.Args()
+	if len(roots) == 0 {
+		return fmt.Errorf("at least one file or directory to embed is required")
+	}
+
+	in, err := os.Open(*exePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	exeLength, err := embeddedzip.FindExecutableEnd(in, info.Size())
+	if err != nil {
+		return fmt.Errorf("checking for an existing embed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(*outPath), ".goembed-append-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once successfully renamed into place
+
+	opts := appender.Options{}
+	if len(arguments) > 0 {
+		opts.Arguments = arguments
+	}
+	if err := appender.Append(in, exeLength, tmp, roots, opts); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, *outPath)
+}
+
+// stringSliceFlag implements flag.Value, collecting repeated -arg flags.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string { return strings.Join(*s, ",") }
+func (s *stringSliceFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -18,47 +18,70 @@ import (
 
 const (
 	kArgumentsFileName = "arguments.txt"
+
+	// kPasswordEnvVar names the environment variable LoadEmbeddedArguments
+	// consults for the password to an AES-encrypted arguments.txt. It is read
+	// from the environment at runtime only; a wrapper that sets it before
+	// exec supplies a fixed password, and LoadEmbeddedArgumentsWithPassword
+	// takes one directly (e.g. a build-time constant).
+	kPasswordEnvVar = "GOEMBED_ARGUMENTS_PASSWORD"
 )
 
 // LoadEmbeddedArguments loads arguments from the embedded zip file into
 // os.Args.
 // No error is returned if the executable file does not have an embedded zip
 // file.
+// If arguments.txt is encrypted with embeddedzip's WinZip AES support, the
+// decryption password is read from the GOEMBED_ARGUMENTS_PASSWORD
+// environment variable. Use LoadEmbeddedArgumentsWithPassword to supply it
+// directly instead.
 func LoadEmbeddedArguments() error {
-	zf, err := embeddedzip.OpenEmbeddedZip()
+	return LoadEmbeddedArgumentsWithPassword(os.Getenv(kPasswordEnvVar))
+}
+
+// LoadEmbeddedArgumentsWithPassword is like LoadEmbeddedArguments, but takes
+// the arguments.txt decryption password explicitly instead of reading it
+// from the environment. Pass an empty string if arguments.txt is not
+// encrypted.
+//
+// It reads arguments.txt through embeddedzip.OpenEmbeddedFileWithPassword,
+// which uses a precomputed index appended by embeddedzip/appender, when
+// present, to fetch just this one file without parsing the rest of the
+// embedded zip.
+func LoadEmbeddedArgumentsWithPassword(password string) error {
+	r, err := embeddedzip.OpenEmbeddedFileWithPassword(kArgumentsFileName, password)
 	if err != nil {
-		if err == embeddedzip.ErrNoFooter {
+		if err == embeddedzip.ErrNoFooter || err == embeddedzip.ErrFileNotFound {
 			return nil
 		}
 		return err
 	}
-	defer zf.Close()
+	defer r.Close()
 
-	for _, f := range zf.File {
-		if f.Name == kArgumentsFileName {
-			r, err := f.Open()
-			if err != nil {
-				return err
-			}
-			defer r.Close()
-			csvr := csv.NewReader(r)
-			csvr.Comma = ' '
+	arguments, err := readArgumentsFile(r)
+	if err != nil {
+		return err
+	}
+	os.Args = append(append([]string{os.Args[0]}, arguments...), os.Args[1:]...)
+	return nil
+}
 
-			var arguments []string
-			for {
-				rec, err := csvr.Read()
-				if err != nil {
-					if err == io.EOF {
-						break
-					}
-					return err
-				}
-				arguments = append(arguments, rec...)
-			}
+// readArgumentsFile parses arguments.txt, read from r, as space-delimited
+// CSV, returning each field of each line as a separate argument.
+func readArgumentsFile(r io.Reader) ([]string, error) {
+	csvr := csv.NewReader(r)
+	csvr.Comma = ' '
 
-			os.Args = append(append([]string{os.Args[0]}, arguments...), os.Args[1:]...)
-			return nil
+	var arguments []string
+	for {
+		rec, err := csvr.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
 		}
+		arguments = append(arguments, rec...)
 	}
-	return nil
+	return arguments, nil
 }
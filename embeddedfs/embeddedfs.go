@@ -0,0 +1,146 @@
+// Package embeddedfs exposes the contents of an embedded zip (as opened by
+// embeddedzip) through the standard io/fs.FS interface, so it can be used
+// directly with http.FS, template.ParseFS, and anything else that accepts
+// an fs.FS. On Linux and macOS, Mount additionally exposes the same files
+// as a real FUSE mount, so external processes -- shell scripts,
+// subprocesses spawned by the embedding Go binary -- can open() bundled
+// resources by path, following the libzipfs pattern.
+//
+// Directory listing and file metadata are served directly by the
+// underlying fs.FS through fs.Stat and fs.ReadDir (typically a *zip.Reader
+// via embeddedzip.ZipReaderCloser, whose Open already serves directories,
+// including synthesized directory entries).
+// FS adds a bounded LRU cache of each file's decompressed content on top,
+// so that repeatedly-opened files don't pay archive/zip's decompression
+// cost more than once.
+package embeddedfs
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// defaultMaxCacheBytes is used by New when maxCacheBytes is <= 0.
+const defaultMaxCacheBytes = 16 * 1024 * 1024
+
+// FS wraps an fs.FS backed by a zip archive, adding a bounded LRU cache of
+// decompressed file content. Files larger than the cache's total budget are
+// always read straight from the underlying FS, uncached.
+type FS struct {
+	inner fs.FS
+
+	mu       sync.Mutex
+	cache    map[string][]byte
+	lru      *list.List
+	elems    map[string]*list.Element
+	size     int64
+	maxBytes int64
+}
+
+// New wraps inner (typically a *zip.Reader, or an *embeddedzip.ZipReaderCloser
+// used as an fs.FS) with a decompressed-content cache bounded to
+// maxCacheBytes total bytes across all cached files. maxCacheBytes <= 0
+// selects a 16 MiB default.
+func New(inner fs.FS, maxCacheBytes int64) *FS {
+	if maxCacheBytes <= 0 {
+		maxCacheBytes = defaultMaxCacheBytes
+	}
+	return &FS{
+		inner:    inner,
+		cache:    make(map[string][]byte),
+		lru:      list.New(),
+		elems:    make(map[string]*list.Element),
+		maxBytes: maxCacheBytes,
+	}
+}
+
+// Open implements fs.FS. Directories, and files too large to ever fit in
+// the cache, are returned by the underlying FS directly and are not cached.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := fs.Stat(f.inner, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() || info.Size() > f.maxBytes {
+		return f.inner.Open(name)
+	}
+
+	data, err := f.getCached(name)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedFile{info: info, r: bytes.NewReader(data)}, nil
+}
+
+// ReadDir implements fs.ReadDirFS by delegating to the underlying FS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.inner, name)
+}
+
+// Stat implements fs.StatFS by delegating to the underlying FS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.inner, name)
+}
+
+// getCached returns name's fully decompressed content, decompressing and
+// caching it on first access and serving it straight from the cache
+// afterward, until it is evicted to make room under maxBytes.
+func (f *FS) getCached(name string) ([]byte, error) {
+	f.mu.Lock()
+	if data, ok := f.cache[name]; ok {
+		f.lru.MoveToFront(f.elems[name])
+		f.mu.Unlock()
+		return data, nil
+	}
+	f.mu.Unlock()
+
+	file, err := f.inner.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Another goroutine may have decompressed and cached name while we were
+	// reading it ourselves; keep whichever copy is already in the cache so
+	// callers observe one consistent slice.
+	if existing, ok := f.cache[name]; ok {
+		return existing, nil
+	}
+	f.cache[name] = data
+	f.elems[name] = f.lru.PushFront(name)
+	f.size += int64(len(data))
+	f.evictLocked()
+	return data, nil
+}
+
+// evictLocked drops the least recently used cached files until the total
+// cached size is back under maxBytes. f.mu must be held.
+func (f *FS) evictLocked() {
+	for f.size > f.maxBytes && f.lru.Len() > 1 {
+		oldest := f.lru.Back()
+		name := oldest.Value.(string)
+		f.size -= int64(len(f.cache[name]))
+		delete(f.cache, name)
+		delete(f.elems, name)
+		f.lru.Remove(oldest)
+	}
+}
+
+// cachedFile serves a cached, already-decompressed file's content.
+type cachedFile struct {
+	info fs.FileInfo
+	r    *bytes.Reader
+}
+
+func (cf *cachedFile) Stat() (fs.FileInfo, error) { return cf.info, nil }
+func (cf *cachedFile) Read(p []byte) (int, error) { return cf.r.Read(p) }
+func (cf *cachedFile) Close() error               { return nil }
@@ -0,0 +1,130 @@
+package embeddedfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func buildTestZip(t *testing.T) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"a.txt":         "hello from a",
+		"dir/b.txt":     "hello from b",
+		"dir/sub/c.txt": "hello from c",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestFSConformance(t *testing.T) {
+	zr := buildTestZip(t)
+	fsys := New(zr, 0)
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenReadAndDir(t *testing.T) {
+	zr := buildTestZip(t)
+	fsys := New(zr, 0)
+
+	f, err := fsys.Open("dir/b.txt")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if string(data) != "hello from b" {
+		t.Fatalf("got %q", data)
+	}
+
+	entries, err := fsys.ReadDir("dir")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %v", len(entries), entries)
+	}
+
+	info, err := fsys.Stat("a.txt")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() != int64(len("hello from a")) {
+		t.Fatalf("got size %d", info.Size())
+	}
+}
+
+func TestCacheServesRepeatedReads(t *testing.T) {
+	zr := buildTestZip(t)
+	fsys := New(zr, 1024)
+
+	for i := 0; i < 3; i++ {
+		f, err := fsys.Open("a.txt")
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "hello from a" {
+			t.Fatalf("got %q", data)
+		}
+	}
+	if _, ok := fsys.cache["a.txt"]; !ok {
+		t.Fatal("expected a.txt to be cached after being read")
+	}
+}
+
+func TestCacheEvictsOverBudget(t *testing.T) {
+	zr := buildTestZip(t)
+	// A budget smaller than two of the three files' combined size, so
+	// reading all three forces at least one eviction.
+	fsys := New(zr, int64(len("hello from a")+len("hello from b")-1))
+
+	for _, name := range []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"} {
+		f, err := fsys.Open(name)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", name, err)
+		}
+		if _, err := io.ReadAll(f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if fsys.size > fsys.maxBytes {
+		t.Fatalf("cache size %d exceeds budget %d after eviction", fsys.size, fsys.maxBytes)
+	}
+	if len(fsys.cache) >= 3 {
+		t.Fatalf("expected at least one eviction, got %d entries cached", len(fsys.cache))
+	}
+}
+
+var _ fs.FS = (*FS)(nil)
+var _ fs.StatFS = (*FS)(nil)
+var _ fs.ReadDirFS = (*FS)(nil)
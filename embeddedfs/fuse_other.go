@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package embeddedfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// ErrFUSEUnsupported is returned by Mount on platforms other than Linux and
+// macOS, where bazil.org/fuse has no kernel support to talk to.
+var ErrFUSEUnsupported = errors.New("embeddedfs: FUSE mounting is only supported on Linux and macOS")
+
+// Mount is unavailable on this platform; use FS (or its underlying fs.FS)
+// directly instead, e.g. with http.FS or template.ParseFS.
+func Mount(ctx context.Context, fsys fs.FS, mountpoint string) error {
+	return ErrFUSEUnsupported
+}
@@ -0,0 +1,117 @@
+//go:build linux || darwin
+
+package embeddedfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+
+	"bazil.org/fuse"
+	fusefs "bazil.org/fuse/fs"
+)
+
+// Mount mounts fsys read-only at mountpoint using FUSE (via bazil.org/fuse),
+// so that external processes can open() its files by path. It blocks,
+// serving requests until ctx is canceled or the filesystem is unmounted out
+// of band (e.g. with fusermount -u on Linux, or umount on macOS).
+func Mount(ctx context.Context, fsys fs.FS, mountpoint string) error {
+	c, err := fuse.Mount(
+		mountpoint,
+		fuse.ReadOnly(),
+		fuse.FSName("embeddedfs"),
+		fuse.Subtype("embeddedfs"),
+	)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- fusefs.Serve(c, &fuseRoot{fsys: fsys})
+	}()
+
+	select {
+	case <-ctx.Done():
+		if err := fuse.Unmount(mountpoint); err != nil {
+			return err
+		}
+		return <-served
+	case err := <-served:
+		return err
+	}
+}
+
+// fuseRoot adapts an fs.FS to bazil.org/fuse's fs.FS interface.
+type fuseRoot struct {
+	fsys fs.FS
+}
+
+func (r *fuseRoot) Root() (fusefs.Node, error) {
+	return &fuseDir{fsys: r.fsys, name: "."}, nil
+}
+
+// fuseDir represents one directory of fsys as a FUSE node.
+type fuseDir struct {
+	fsys fs.FS
+	name string
+}
+
+func (d *fuseDir) Attr(ctx context.Context, a *fuse.Attr) error {
+	a.Mode = fs.ModeDir | 0o555
+	return nil
+}
+
+func (d *fuseDir) Lookup(ctx context.Context, name string) (fusefs.Node, error) {
+	full := path.Join(d.name, name)
+	info, err := fs.Stat(d.fsys, full)
+	if err != nil {
+		return nil, fuse.ENOENT
+	}
+	if info.IsDir() {
+		return &fuseDir{fsys: d.fsys, name: full}, nil
+	}
+	return &fuseFile{fsys: d.fsys, name: full, size: info.Size()}, nil
+}
+
+func (d *fuseDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	entries, err := fs.ReadDir(d.fsys, d.name)
+	if err != nil {
+		return nil, err
+	}
+	dirents := make([]fuse.Dirent, 0, len(entries))
+	for _, e := range entries {
+		typ := fuse.DT_File
+		if e.IsDir() {
+			typ = fuse.DT_Dir
+		}
+		dirents = append(dirents, fuse.Dirent{Name: e.Name(), Type: typ})
+	}
+	return dirents, nil
+}
+
+// fuseFile represents one file of fsys as a FUSE node, reading its full,
+// decompressed content on demand (relying on FS's own cache, when fsys is
+// one, to avoid repeated decompression).
+type fuseFile struct {
+	fsys fs.FS
+	name string
+	size int64
+}
+
+func (f *fuseFile) Attr(ctx context.Context, a *fuse.Attr) error {
+	a.Mode = 0o444
+	a.Size = uint64(f.size)
+	return nil
+}
+
+func (f *fuseFile) ReadAll(ctx context.Context) ([]byte, error) {
+	file, err := f.fsys.Open(f.name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
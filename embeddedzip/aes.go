@@ -0,0 +1,236 @@
+package embeddedzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrAESBadPassword is returned when the password verification value
+	// stored alongside a WinZip AES-encrypted entry does not match the
+	// supplied password, which almost always means the password is wrong.
+	ErrAESBadPassword = errors.New("embeddedzip: incorrect password for AES-encrypted file")
+	// ErrAESAuthFailed is returned when an AES-encrypted entry's HMAC-SHA1
+	// authentication code does not match its ciphertext, meaning the entry
+	// is corrupt or has been tampered with.
+	ErrAESAuthFailed = errors.New("embeddedzip: AES authentication code mismatch; file is corrupt or was tampered with")
+	// ErrFileNotFound is returned by ZipReaderCloser.OpenDecrypted when no
+	// entry with the given name exists in the zip.
+	ErrFileNotFound = errors.New("embeddedzip: file not found in embedded zip")
+)
+
+const (
+	aesExtraHeaderID = 0x9901
+	aesAuthCodeSize  = 10
+	aesKDFIterations = 1000
+)
+
+// aesExtraInfo is the information carried by a WinZip AES extra field
+// (header ID 0x9901, APPNOTE.TXT section 4.6.3). It replaces the
+// CompressionMethod and CRC-32 that archive/zip reads from the regular
+// header fields, both of which are repurposed for AES entries.
+type aesExtraInfo struct {
+	strength     byte
+	actualMethod uint16
+}
+
+// parseAESExtra scans a zip.File's raw Extra field for a WinZip AES record.
+func parseAESExtra(extra []byte) (aesExtraInfo, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			return aesExtraInfo{}, false
+		}
+		data := extra[4 : 4+size]
+		if id == aesExtraHeaderID && size == 7 {
+			return aesExtraInfo{
+				strength:     data[4],
+				actualMethod: binary.LittleEndian.Uint16(data[5:7]),
+			}, true
+		}
+		extra = extra[4+size:]
+	}
+	return aesExtraInfo{}, false
+}
+
+// aesKeySizes returns the AES key size and salt size in bytes for the
+// strength byte carried by an AE-1/AE-2 extra field (1 = AES-128, 2 =
+// AES-192, 3 = AES-256).
+func aesKeySizes(strength byte) (keySize, saltSize int, ok bool) {
+	switch strength {
+	case 1:
+		return 16, 8, true
+	case 2:
+		return 24, 12, true
+	case 3:
+		return 32, 16, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// pbkdf2HMACSHA1 derives keyLen bytes of key material from password and salt
+// using PBKDF2 (RFC 2898) with HMAC-SHA1 as the pseudorandom function, as
+// required by the WinZip AES key derivation scheme. It is small and specific
+// enough to this one call site that vendoring golang.org/x/crypto/pbkdf2 for
+// it did not seem worth the extra dependency.
+func pbkdf2HMACSHA1(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var blockNum [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(blockNum[:], uint32(block))
+		prf.Write(blockNum[:])
+		dk = prf.Sum(dk)
+
+		t := dk[len(dk)-hashLen:]
+		u := append([]byte(nil), t...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
+
+// openPossiblyEncrypted opens f, decrypting it with password first if f
+// carries a WinZip AES extra field (header ID 0x9901). Files without that
+// extra field are opened normally; password is ignored for them.
+func openPossiblyEncrypted(f *zip.File, password string) (io.ReadCloser, error) {
+	info, ok := parseAESExtra(f.Extra)
+	if !ok {
+		// Method 99 (AES) without the 0x9901 extra field that describes it is
+		// not a valid archive, not legacy encryption; a conformant encoder
+		// never writes one without the other.
+		if f.Method == 99 {
+			return nil, fmt.Errorf("embeddedzip: %s has method 99 (AES) but no AES extra field; archive is corrupt", f.Name)
+		}
+		// General-purpose bit 0 marks an entry as encrypted; with no AES
+		// extra field, that means the legacy PKZIP "standard" encryption,
+		// which is not cryptographically secure and is not supported.
+		if f.Flags&0x1 != 0 {
+			return nil, fmt.Errorf("embeddedzip: %s uses unsupported legacy PKZIP encryption, not WinZip AES", f.Name)
+		}
+		return f.Open()
+	}
+
+	keySize, saltSize, ok := aesKeySizes(info.strength)
+	if !ok {
+		return nil, fmt.Errorf("embeddedzip: %s has unknown AES strength flag %d", f.Name, info.strength)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	overhead := saltSize + 2 + aesAuthCodeSize
+	if len(data) < overhead {
+		return nil, fmt.Errorf("embeddedzip: %s is too short to be a valid AES-encrypted entry", f.Name)
+	}
+	salt := data[:saltSize]
+	passwordVerify := data[saltSize : saltSize+2]
+	ciphertext := data[saltSize+2 : len(data)-aesAuthCodeSize]
+	authCode := data[len(data)-aesAuthCodeSize:]
+
+	derived := pbkdf2HMACSHA1([]byte(password), salt, aesKDFIterations, 2*keySize+2)
+	encKey := derived[:keySize]
+	hmacKey := derived[keySize : 2*keySize]
+	verifyValue := derived[2*keySize:]
+
+	if subtle.ConstantTimeCompare(verifyValue, passwordVerify) != 1 {
+		return nil, ErrAESBadPassword
+	}
+
+	mac := hmac.New(sha1.New, hmacKey)
+	mac.Write(ciphertext)
+	computedAuth := mac.Sum(nil)[:aesAuthCodeSize]
+	if subtle.ConstantTimeCompare(computedAuth, authCode) != 1 {
+		return nil, ErrAESAuthFailed
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	// WinZip AES uses AES-CTR with a 16-byte counter block that starts at 1
+	// and is incremented as a little-endian integer. cipher.NewCTR increments
+	// its counter big-endian, so the keystream is generated here directly.
+	plain := make([]byte, len(ciphertext))
+	var counter, keystream [aes.BlockSize]byte
+	for off := 0; off < len(ciphertext); off += aes.BlockSize {
+		for i := range counter {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+		block.Encrypt(keystream[:], counter[:])
+		for i := off; i < len(ciphertext) && i < off+aes.BlockSize; i++ {
+			plain[i] = ciphertext[i] ^ keystream[i-off]
+		}
+	}
+
+	switch info.actualMethod {
+	case zip.Store:
+		return io.NopCloser(bytes.NewReader(plain)), nil
+	case zip.Deflate:
+		return flate.NewReader(bytes.NewReader(plain)), nil
+	default:
+		return nil, fmt.Errorf("embeddedzip: %s uses unsupported AES inner compression method %d", f.Name, info.actualMethod)
+	}
+}
+
+// OpenDecrypted returns a reader for the decrypted, decompressed content of
+// the named entry, applying the password set via OpenEmbeddedZipWithPassword
+// if the entry carries a WinZip AES extra field. It returns ErrFileNotFound
+// if no entry with that name exists.
+//
+// This is named OpenDecrypted, rather than Open, so that it does not shadow
+// the Open(name string) (fs.File, error) method ZipReaderCloser otherwise
+// promotes from its embedded *zip.Reader; ZipReaderCloser must keep
+// satisfying fs.FS for embeddedfs and http.FS to work with it.
+func (zr *ZipReaderCloser) OpenDecrypted(name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return openPossiblyEncrypted(f, zr.password)
+		}
+	}
+	return nil, ErrFileNotFound
+}
+
+// OpenEmbeddedZipWithPassword behaves like OpenEmbeddedZip, but the returned
+// ZipReaderCloser decrypts WinZip AES-encrypted entries opened through its
+// OpenDecrypted method using pw; its Open method does not decrypt. Legacy PKZIP "standard" encryption is intentionally
+// not supported, as it is not secure.
+func OpenEmbeddedZipWithPassword(pw string) (*ZipReaderCloser, error) {
+	zrc, err := OpenEmbeddedZip()
+	if err != nil {
+		return nil, err
+	}
+	zrc.password = pw
+	return zrc, nil
+}
@@ -0,0 +1,186 @@
+package embeddedzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// buildAESEntry hand-constructs the raw bytes of a single WinZip AES-256
+// (AE-2, strength 3) encrypted, stored (uncompressed) entry for name/content
+// under password, the way a conformant archiver like WinZip or 7-Zip would.
+func buildAESEntry(t *testing.T, name, password string, content []byte) []byte {
+	t.Helper()
+	const strength = 3 // AES-256
+	keySize, saltSize, ok := aesKeySizes(strength)
+	if !ok {
+		t.Fatalf("bad strength")
+	}
+	salt := bytes.Repeat([]byte{0x42}, saltSize)
+
+	derived := pbkdf2HMACSHA1([]byte(password), salt, aesKDFIterations, 2*keySize+2)
+	encKey := derived[:keySize]
+	hmacKey := derived[keySize : 2*keySize]
+	passwordVerify := derived[2*keySize:]
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// AES-CTR with a little-endian counter block starting at 1.
+	ciphertext := make([]byte, len(content))
+	var counter, keystream [aes.BlockSize]byte
+	for off := 0; off < len(content); off += aes.BlockSize {
+		for i := range counter {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+		block.Encrypt(keystream[:], counter[:])
+		for i := off; i < len(content) && i < off+aes.BlockSize; i++ {
+			ciphertext[i] = content[i] ^ keystream[i-off]
+		}
+	}
+
+	mac := hmac.New(sha1.New, hmacKey)
+	mac.Write(ciphertext)
+	authCode := mac.Sum(nil)[:aesAuthCodeSize]
+
+	var buf bytes.Buffer
+	buf.Write(salt)
+	buf.Write(passwordVerify)
+	buf.Write(ciphertext)
+	buf.Write(authCode)
+	return buf.Bytes()
+}
+
+// aesExtraField builds the raw 0x9901 extra field bytes for strength 3
+// (AES-256) with the given inner (actual) compression method.
+func aesExtraField(actualMethod uint16) []byte {
+	var buf bytes.Buffer
+	var u16 [2]byte
+	binary.LittleEndian.PutUint16(u16[:], 0x9901)
+	buf.Write(u16[:])
+	binary.LittleEndian.PutUint16(u16[:], 7) // data size
+	buf.Write(u16[:])
+	binary.LittleEndian.PutUint16(u16[:], 2) // AE-2 (no CRC check)
+	buf.Write(u16[:])
+	buf.WriteString("AE")
+	buf.WriteByte(3) // strength: AES-256
+	binary.LittleEndian.PutUint16(u16[:], actualMethod)
+	buf.Write(u16[:])
+	return buf.Bytes()
+}
+
+func buildAESZip(t *testing.T, name, password string, content []byte) []byte {
+	t.Helper()
+	raw := buildAESEntry(t, name, password, content)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	hdr := &zip.FileHeader{
+		Name:               name,
+		Method:             99, // AES
+		Extra:              aesExtraField(zip.Store),
+		CompressedSize64:   uint64(len(raw)),
+		UncompressedSize64: uint64(len(content)),
+	}
+	w, err := zw.CreateRaw(hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAESRoundTrip(t *testing.T) {
+	const password = "hunter2"
+	content := []byte("the secret embedded payload")
+	zipBytes := buildAESZip(t, "secret.txt", password, content)
+
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	zrc := &ZipReaderCloser{Reader: zr, password: password}
+
+	r, err := zrc.OpenDecrypted("secret.txt")
+	if err != nil {
+		t.Fatalf("OpenDecrypted: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("got %q, want %q", got, content)
+	}
+}
+
+func TestAESRoundTripBadPassword(t *testing.T) {
+	zipBytes := buildAESZip(t, "secret.txt", "correct horse", []byte("data"))
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zrc := &ZipReaderCloser{Reader: zr, password: "wrong password"}
+	if _, err := zrc.OpenDecrypted("secret.txt"); err != ErrAESBadPassword {
+		t.Fatalf("got %v, want ErrAESBadPassword", err)
+	}
+}
+
+// buildLegacyEncryptedZip constructs an entry with the legacy PKZIP
+// "standard encryption" general-purpose flag set and no AES extra field --
+// the header shape a ZipCrypto-encrypted archive has -- without actually
+// implementing that scheme, which openPossiblyEncrypted must refuse rather
+// than attempt to inflate as if it were plaintext.
+func buildLegacyEncryptedZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	hdr := &zip.FileHeader{
+		Name:               "legacy.txt",
+		Method:             zip.Store,
+		Flags:              0x1, // encrypted
+		CompressedSize64:   12,
+		UncompressedSize64: 12,
+	}
+	w, err := zw.CreateRaw(hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 12 arbitrary bytes standing in for ZipCrypto's encryption header plus
+	// ciphertext; their content doesn't matter since this must be rejected
+	// before any attempt to decrypt or inflate them.
+	if _, err := w.Write(bytes.Repeat([]byte{0xAA}, 12)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLegacyEncryptionRejected(t *testing.T) {
+	zipBytes := buildLegacyEncryptedZip(t)
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zrc := &ZipReaderCloser{Reader: zr}
+	_, err = zrc.OpenDecrypted("legacy.txt")
+	if err == nil {
+		t.Fatal("expected an error opening a legacy-encrypted entry, got nil")
+	}
+}
@@ -0,0 +1,158 @@
+// Package appender builds embedded-zip executables: given an existing Go
+// binary and a set of files, it writes a copy of the binary with a fresh
+// zip, and a self-check trailer that embeddedzip validates before trusting
+// the embed, appended to it. cmd/goembed-append is a thin command-line
+// wrapper around it.
+package appender
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/interarticle/goembed/embeddedzip"
+	"github.com/interarticle/goembed/embeddedzip/index"
+)
+
+// Options configures Append.
+type Options struct {
+	// Arguments, if non-nil, is written to arguments.txt at the root of the
+	// embedded zip, for embeddedargs.LoadEmbeddedArguments to pick up.
+	Arguments []string
+}
+
+// Append writes a copy of the executable read from exe (exeLength bytes
+// long; use embeddedzip.FindExecutableEnd to compute this when exe may
+// already have an embed, so the old one is dropped rather than stacked
+// under the new one) to out, followed by a fresh zip archive of every file
+// found under each of roots, a precomputed index of that zip's entries (see
+// embeddedzip/index) for embeddedzip.OpenEmbeddedFile to use, and a
+// self-check trailer that embeddedzip.OpenEmbeddedZip and OpenExecutable
+// validate before trusting the embed.
+func Append(exe io.ReaderAt, exeLength int64, out io.Writer, roots []string, opts Options) error {
+	if _, err := io.Copy(out, io.NewSectionReader(exe, 0, exeLength)); err != nil {
+		return fmt.Errorf("appender: copying executable: %w", err)
+	}
+
+	// The zip is built into a buffer, rather than streamed straight to out,
+	// so it can be re-read afterwards to compute the local file header
+	// offsets the index needs; archive/zip.Writer doesn't expose those as it
+	// writes.
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+
+	if opts.Arguments != nil {
+		w, err := zw.Create("arguments.txt")
+		if err != nil {
+			return fmt.Errorf("appender: creating arguments.txt: %w", err)
+		}
+		// embeddedargs parses arguments.txt as space-delimited CSV, so each
+		// argument is written as one CSV field, quoted where needed.
+		csvw := csv.NewWriter(w)
+		csvw.Comma = ' '
+		if err := csvw.Write(opts.Arguments); err != nil {
+			return fmt.Errorf("appender: writing arguments.txt: %w", err)
+		}
+		csvw.Flush()
+		if err := csvw.Error(); err != nil {
+			return fmt.Errorf("appender: writing arguments.txt: %w", err)
+		}
+	}
+
+	for _, root := range roots {
+		if err := addTree(zw, root); err != nil {
+			return fmt.Errorf("appender: adding %s: %w", root, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("appender: closing zip: %w", err)
+	}
+	zipLength := int64(zipBuf.Len())
+
+	zr, err := zip.NewReader(bytes.NewReader(zipBuf.Bytes()), zipLength)
+	if err != nil {
+		return fmt.Errorf("appender: re-reading zip to build its index: %w", err)
+	}
+	entries, err := index.EntriesFromZip(zr)
+	if err != nil {
+		return fmt.Errorf("appender: building index: %w", err)
+	}
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(out, h)}
+	if _, err := io.Copy(cw, &zipBuf); err != nil {
+		return fmt.Errorf("appender: writing zip: %w", err)
+	}
+	if _, err := index.Write(cw, zipLength, entries); err != nil {
+		return fmt.Errorf("appender: writing index: %w", err)
+	}
+
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	if err := embeddedzip.WriteSelfCheckFooter(out, cw.n, sum); err != nil {
+		return fmt.Errorf("appender: writing self-check trailer: %w", err)
+	}
+	return nil
+}
+
+// addTree adds every regular file under root to zw. Entries are named
+// root's own base name joined with their path relative to root, so the
+// embedded layout mirrors the paths passed on the command line and files
+// from different roots with the same relative path don't collide.
+func addTree(zw *zip.Writer, root string) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(root)
+	if !info.IsDir() {
+		return addFile(zw, base, root)
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return addFile(zw, filepath.ToSlash(filepath.Join(base, rel)), p)
+	})
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// countingWriter wraps an io.Writer, tracking how many bytes have been
+// written so Append knows the length of the zip plus its index to record in
+// the self-check trailer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
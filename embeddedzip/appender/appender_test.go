@@ -0,0 +1,191 @@
+package appender
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/interarticle/goembed/embeddedzip"
+)
+
+// fakeExe returns bytes that stand in for an executable: OpenExecutable
+// doesn't recognize them as ELF/Mach-O/PE, so it falls back to treating the
+// whole file as the tail-search region, exactly like OpenEmbeddedZip does.
+func fakeExe(content string) []byte {
+	return []byte(content)
+}
+
+func writeAssets(t *testing.T, dir string) string {
+	t.Helper()
+	root := filepath.Join(dir, "assets")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hello from asset"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestAppendAndReadBack(t *testing.T) {
+	dir := t.TempDir()
+	assets := writeAssets(t, dir)
+	exe := fakeExe("#!/bin/fake-executable\n")
+
+	var out bytes.Buffer
+	if err := Append(bytes.NewReader(exe), int64(len(exe)), &out, []string{assets}, Options{
+		Arguments: []string{"--flag1", "val1"},
+	}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	binPath := filepath.Join(dir, "embedded.bin")
+	if err := os.WriteFile(binPath, out.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	zrc, err := embeddedzip.OpenExecutable(binPath)
+	if err != nil {
+		t.Fatalf("OpenExecutable: %v", err)
+	}
+	defer zrc.Close()
+
+	names := map[string]bool{}
+	for _, f := range zrc.File {
+		names[f.Name] = true
+	}
+	if !names["arguments.txt"] || !names["assets/hello.txt"] {
+		t.Fatalf("missing expected entries, got %v", names)
+	}
+
+	f, err := zrc.Open("assets/hello.txt")
+	if err != nil {
+		t.Fatalf("zip Open: %v", err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello from asset" {
+		t.Fatalf("got %q", data)
+	}
+}
+
+func TestAppendOverwritesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	assetsV1 := writeAssets(t, dir)
+	exe := fakeExe("fake executable bytes")
+
+	var out1 bytes.Buffer
+	if err := Append(bytes.NewReader(exe), int64(len(exe)), &out1, []string{assetsV1}, Options{}); err != nil {
+		t.Fatalf("Append v1: %v", err)
+	}
+
+	// Overwrite in place with a second embed, using FindExecutableEnd to
+	// locate where the real executable ends within the first embed, the way
+	// cmd/goembed-append does.
+	assetsV2 := filepath.Join(dir, "assets2")
+	if err := os.MkdirAll(assetsV2, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assetsV2, "v2.txt"), []byte("version 2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data1 := out1.Bytes()
+	exeEnd, err := embeddedzip.FindExecutableEnd(bytes.NewReader(data1), int64(len(data1)))
+	if err != nil {
+		t.Fatalf("FindExecutableEnd: %v", err)
+	}
+	if exeEnd != int64(len(exe)) {
+		t.Fatalf("FindExecutableEnd = %d, want %d (original executable length)", exeEnd, len(exe))
+	}
+
+	var out2 bytes.Buffer
+	if err := Append(bytes.NewReader(data1), exeEnd, &out2, []string{assetsV2}, Options{}); err != nil {
+		t.Fatalf("Append v2: %v", err)
+	}
+
+	binPath := filepath.Join(dir, "embedded.bin")
+	if err := os.WriteFile(binPath, out2.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	zrc, err := embeddedzip.OpenExecutable(binPath)
+	if err != nil {
+		t.Fatalf("OpenExecutable: %v", err)
+	}
+	defer zrc.Close()
+
+	var names []string
+	for _, f := range zrc.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 1 || names[0] != "assets2/v2.txt" {
+		t.Fatalf("expected only the v2 embed to remain, got %v", names)
+	}
+}
+
+func TestAppendDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	assets := writeAssets(t, dir)
+	exe := fakeExe("fake executable bytes")
+
+	var out bytes.Buffer
+	if err := Append(bytes.NewReader(exe), int64(len(exe)), &out, []string{assets}, Options{}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	tampered := append([]byte(nil), out.Bytes()...)
+	// Flip a byte before the trailer, inside the precomputed index that the
+	// trailer's SHA-256 covers along with the zip.
+	pos := len(tampered) - 100
+	tampered[pos] ^= 0xFF
+
+	binPath := filepath.Join(dir, "embedded.bin")
+	if err := os.WriteFile(binPath, tampered, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := embeddedzip.OpenExecutable(binPath); err != embeddedzip.ErrSelfCheckFailed {
+		t.Fatalf("got %v, want ErrSelfCheckFailed", err)
+	}
+}
+
+// TestAppendWritesUsableIndex confirms Append's precomputed index (see
+// embeddedzip/index) is actually wired up end-to-end: embeddedzip.
+// OpenEmbeddedFile, reading os.Args[0], must be able to fetch a single
+// embedded asset from an Append-produced binary.
+func TestAppendWritesUsableIndex(t *testing.T) {
+	dir := t.TempDir()
+	assets := writeAssets(t, dir)
+	exe := fakeExe("#!/bin/fake-executable\n")
+
+	var out bytes.Buffer
+	if err := Append(bytes.NewReader(exe), int64(len(exe)), &out, []string{assets}, Options{}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	binPath := filepath.Join(dir, "embedded.bin")
+	if err := os.WriteFile(binPath, out.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	origArgs0 := os.Args[0]
+	os.Args[0] = binPath
+	defer func() { os.Args[0] = origArgs0 }()
+
+	r, err := embeddedzip.OpenEmbeddedFile("assets/hello.txt")
+	if err != nil {
+		t.Fatalf("OpenEmbeddedFile: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello from asset" {
+		t.Fatalf("got %q, want %q", data, "hello from asset")
+	}
+}
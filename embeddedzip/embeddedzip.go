@@ -10,6 +10,7 @@ package embeddedzip
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -17,12 +18,24 @@ import (
 )
 
 var (
-	ErrNoFooter = errors.New("cannot find zip footer; file does not have embedded zip or zip file has comment")
+	// ErrNoFooter is returned when the end of central directory record of an
+	// embedded zip file cannot be located in the file at all.
+	ErrNoFooter = errors.New("cannot find zip footer; file does not have embedded zip")
 )
 
-const zipFooterSignature = 0x06054b50
+const (
+	eocdSignature       = 0x06054b50
+	zip64LocatorSig     = 0x07064b50
+	zip64EOCDSignature  = 0x06064b50
+	eocdRecordSize      = 22
+	zip64LocatorSize    = 20
+	zip64EOCDRecordSize = 56
+	maxCommentSize      = 0xffff
+)
 
-type zipFooter struct {
+// eocdRecord mirrors the fixed-size portion of the end of central directory
+// record, excluding the variable-length comment that follows it.
+type eocdRecord struct {
 	Signature            uint32
 	DiskNum              uint16
 	DiskStart            uint16
@@ -33,30 +46,175 @@ type zipFooter struct {
 	CommentLength        uint16
 }
 
-var zipFooterSize = binary.Size(zipFooter{})
-
-func (f *zipFooter) Verify() error {
-	if f.Signature != zipFooterSignature || f.CommentLength != 0 {
-		return ErrNoFooter
-	}
-	return nil
+// zip64Locator mirrors the zip64 end of central directory locator, which
+// immediately precedes the regular eocdRecord when the archive uses zip64.
+type zip64Locator struct {
+	Signature         uint32
+	DiskWithZip64EOCD uint32
+	Zip64EOCDOffset   uint64
+	TotalDisks        uint32
 }
 
-func (f *zipFooter) FileSize() int64 {
-	return int64(uint32(zipFooterSize) + f.DirectorySize + f.DirectoryStartOffset)
+// zip64EOCDRecord mirrors the fixed-size header of the zip64 end of central
+// directory record; the extensible data sector that may follow it is ignored.
+type zip64EOCDRecord struct {
+	Signature            uint32
+	SizeOfEOCD64         uint64
+	VersionMadeBy        uint16
+	VersionNeeded        uint16
+	DiskNum              uint32
+	DiskStart            uint32
+	NumRecordsThisDisk   uint64
+	TotalRecords         uint64
+	DirectorySize        uint64
+	DirectoryStartOffset uint64
 }
 
-func (f *zipFooter) CalculateStartOffset(totalLength int64) (int64, error) {
-	zipSize := f.FileSize()
-	if zipSize > totalLength {
-		return 0, ErrNoFooter
+// locateEOCD searches the tail of the file for the end of central directory
+// record, matching the signature and verifying that CommentLength accounts
+// for exactly the remaining bytes of the file. This tolerates archives with
+// arbitrary comment bytes, including ones that happen to contain the EOCD
+// signature.
+func locateEOCD(ra io.ReaderAt, length int64) (offset int64, rec eocdRecord, err error) {
+	windowSize := int64(eocdRecordSize + maxCommentSize)
+	if windowSize > length {
+		windowSize = length
+	}
+	if windowSize < eocdRecordSize {
+		return 0, eocdRecord{}, ErrNoFooter
+	}
+
+	buf := make([]byte, windowSize)
+	if _, err := ra.ReadAt(buf, length-windowSize); err != nil {
+		return 0, eocdRecord{}, err
 	}
-	return totalLength - zipSize, nil
+
+	for i := len(buf) - eocdRecordSize; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(buf[i:]) != eocdSignature {
+			continue
+		}
+		commentLength := binary.LittleEndian.Uint16(buf[i+20:])
+		if i+eocdRecordSize+int(commentLength) != len(buf) {
+			continue
+		}
+		var candidate eocdRecord
+		if err := binary.Read(bytes.NewReader(buf[i:i+eocdRecordSize]), binary.LittleEndian, &candidate); err != nil {
+			return 0, eocdRecord{}, err
+		}
+		return length - windowSize + int64(i), candidate, nil
+	}
+	return 0, eocdRecord{}, ErrNoFooter
 }
 
 type ZipReaderCloser struct {
 	*zip.Reader
 	io.Closer
+
+	// password is used by OpenDecrypted to decrypt WinZip AES-encrypted entries; it
+	// is set by OpenEmbeddedZipWithPassword and otherwise left empty.
+	password string
+}
+
+// zipBounds searches the tail of ra (which has the given total length) for
+// an end of central directory record, following a zip64 locator if present,
+// and returns the offset at which the archive starts and how long it is.
+func zipBounds(ra io.ReaderAt, length int64) (startOffset, zipLength int64, err error) {
+	eocdOffset, eocd, err := locateEOCD(ra, length)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	directorySize := int64(eocd.DirectorySize)
+	directoryStartOffset := int64(eocd.DirectoryStartOffset)
+	directoryEndOffset := eocdOffset
+
+	// A directory size/offset of 0xffffffff signals that the real values live
+	// in the zip64 end of central directory record, which is pointed to by a
+	// locator that immediately precedes the regular EOCD record.
+	if eocd.DirectorySize == 0xffffffff || eocd.DirectoryStartOffset == 0xffffffff {
+		if eocdOffset < zip64LocatorSize {
+			return 0, 0, ErrNoFooter
+		}
+		locatorOffset := eocdOffset - zip64LocatorSize
+		var locator zip64Locator
+		locatorBuf := make([]byte, zip64LocatorSize)
+		if _, err := ra.ReadAt(locatorBuf, locatorOffset); err != nil {
+			return 0, 0, err
+		}
+		if err := binary.Read(bytes.NewReader(locatorBuf), binary.LittleEndian, &locator); err != nil {
+			return 0, 0, err
+		}
+		if locator.Signature != zip64LocatorSig {
+			return 0, 0, ErrNoFooter
+		}
+
+		// locator.Zip64EOCDOffset is relative to the start of the zip archive
+		// itself, like DirectoryStartOffset, not to ra -- it is only directly
+		// usable as a read offset when the zip isn't embedded after other
+		// data. The zip64 end of central directory record always immediately
+		// precedes its locator on disk, so its true position in ra is
+		// derived the same way the locator's own position was, by scanning
+		// backward from something we've already found.
+		zip64EOCDOffset := locatorOffset - zip64EOCDRecordSize
+		if zip64EOCDOffset < 0 {
+			return 0, 0, ErrNoFooter
+		}
+		zip64Buf := make([]byte, zip64EOCDRecordSize)
+		if _, err := ra.ReadAt(zip64Buf, zip64EOCDOffset); err != nil {
+			return 0, 0, err
+		}
+		var zip64EOCD zip64EOCDRecord
+		if err := binary.Read(bytes.NewReader(zip64Buf), binary.LittleEndian, &zip64EOCD); err != nil {
+			return 0, 0, err
+		}
+		if zip64EOCD.Signature != zip64EOCDSignature {
+			return 0, 0, ErrNoFooter
+		}
+
+		directorySize = int64(zip64EOCD.DirectorySize)
+		directoryStartOffset = int64(zip64EOCD.DirectoryStartOffset)
+		directoryEndOffset = zip64EOCDOffset
+	}
+
+	startOffset = directoryEndOffset - directorySize - directoryStartOffset
+	if startOffset < 0 || startOffset > length {
+		return 0, 0, ErrNoFooter
+	}
+	return startOffset, length - startOffset, nil
+}
+
+// zipFromTail is zipBounds followed by constructing a zip.Reader over
+// exactly the bytes it locates.
+func zipFromTail(ra io.ReaderAt, length int64) (*zip.Reader, error) {
+	startOffset, zipLength, err := zipBounds(ra, length)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(io.NewSectionReader(ra, startOffset, zipLength), zipLength)
+}
+
+// FindExecutableEnd returns the offset at which the "real" executable ends
+// within a file of the given total length, read through ra: the start of an
+// existing embedded zip (and self-check trailer, if any), or length itself
+// if ra has no embedded zip at all. embeddedzip/appender uses this to
+// overwrite an existing embed in place instead of stacking a new one after
+// the old one.
+func FindExecutableEnd(ra io.ReaderAt, length int64) (int64, error) {
+	strippedLength, err := stripSelfCheckFooter(ra, length)
+	if err != nil {
+		return 0, err
+	}
+	if _, zipLength, ok := stripIndex(ra, strippedLength); ok {
+		strippedLength = zipLength
+	}
+	startOffset, _, err := zipBounds(ra, strippedLength)
+	if err != nil {
+		if err == ErrNoFooter {
+			return length, nil
+		}
+		return 0, err
+	}
+	return startOffset, nil
 }
 
 // OpenEmbeddedZip opens and returns the zip file embedded in the current go
@@ -72,35 +230,23 @@ func OpenEmbeddedZip() (*ZipReaderCloser, error) {
 			f.Close()
 		}
 	}()
-	length, err := f.Seek(0, 2)
-	if err != nil {
-		return nil, err
-	}
-	if length < int64(zipFooterSize) {
-		return nil, ErrNoFooter
-	}
 
-	_, err = f.Seek(-int64(zipFooterSize), 1)
+	length, err := f.Seek(0, io.SeekEnd)
 	if err != nil {
 		return nil, err
 	}
 
-	var footer zipFooter
-	err = binary.Read(f, binary.LittleEndian, &footer)
+	length, err = stripSelfCheckFooter(f, length)
 	if err != nil {
 		return nil, err
 	}
-	err = footer.Verify()
-	if err != nil {
-		return nil, err
+	if _, zipLength, ok := stripIndex(f, length); ok {
+		length = zipLength
 	}
 
-	startOffset, err := footer.CalculateStartOffset(length)
+	zipReader, err := zipFromTail(f, length)
 	if err != nil {
 		return nil, err
 	}
-
-	reader := io.NewSectionReader(f, startOffset, footer.FileSize())
-	zipReader, err := zip.NewReader(reader, footer.FileSize())
-	return &ZipReaderCloser{zipReader, f}, nil
+	return &ZipReaderCloser{Reader: zipReader, Closer: f}, nil
 }
@@ -0,0 +1,171 @@
+package embeddedzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+var le = binary.LittleEndian
+
+// buildZip returns a minimal zip archive containing one entry.
+func buildZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestZipFromTailPlain(t *testing.T) {
+	exe := []byte("#!/bin/fake-executable\n")
+	zipBytes := buildZip(t)
+	data := append(append([]byte(nil), exe...), zipBytes...)
+
+	zr, err := zipFromTail(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zipFromTail: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "hello.txt" {
+		t.Fatalf("unexpected entries: %+v", zr.File)
+	}
+}
+
+func TestZipFromTailWithComment(t *testing.T) {
+	exe := []byte("fake executable bytes")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("a"))
+	// Pick a comment that itself contains bytes matching the EOCD signature,
+	// to make sure locateEOCD doesn't stop at the first match it finds
+	// scanning backward from the wrong end.
+	zw.SetComment(strings.Repeat("PK\x05\x06 trailing comment with embedded signature-like bytes ", 10))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := append(append([]byte(nil), exe...), buf.Bytes()...)
+
+	zr, err := zipFromTail(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zipFromTail: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "a.txt" {
+		t.Fatalf("unexpected entries: %+v", zr.File)
+	}
+}
+
+func TestZipFromTailNoFooter(t *testing.T) {
+	_, err := zipFromTail(bytes.NewReader([]byte("not a zip at all")), 16)
+	if err != ErrNoFooter {
+		t.Fatalf("got %v, want ErrNoFooter", err)
+	}
+}
+
+// buildZip64 constructs a minimal zip64 archive by hand: a single stored
+// entry followed by a zip64 end of central directory record, zip64 locator,
+// and a regular EOCD record whose directory size/offset fields are forced to
+// the zip64 sentinel value 0xffffffff.
+func buildZip64(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+
+	name := "z.txt"
+	content := []byte("zip64!")
+
+	localHeaderOffset := int64(buf.Len())
+	writeUint32 := func(v uint32) { var b [4]byte; le.PutUint32(b[:], v); buf.Write(b[:]) }
+	writeUint16 := func(v uint16) { var b [2]byte; le.PutUint16(b[:], v); buf.Write(b[:]) }
+
+	writeUint32(0x04034b50) // local file header signature
+	writeUint16(20)         // version needed
+	writeUint16(0)          // flags
+	writeUint16(0)          // method: store
+	writeUint16(0)          // mod time
+	writeUint16(0)          // mod date
+	writeUint32(0)          // crc32 (not checked by our scanner)
+	writeUint32(uint32(len(content)))
+	writeUint32(uint32(len(content)))
+	writeUint16(uint16(len(name)))
+	writeUint16(0) // extra length
+	buf.WriteString(name)
+	buf.Write(content)
+
+	centralDirStart := int64(buf.Len())
+	writeUint32(0x02014b50) // central file header signature
+	writeUint16(20)         // version made by
+	writeUint16(20)         // version needed
+	writeUint16(0)          // flags
+	writeUint16(0)          // method
+	writeUint16(0)          // mod time
+	writeUint16(0)          // mod date
+	writeUint32(0)          // crc32
+	writeUint32(uint32(len(content)))
+	writeUint32(uint32(len(content)))
+	writeUint16(uint16(len(name)))
+	writeUint16(0) // extra length
+	writeUint16(0) // comment length
+	writeUint16(0) // disk number start
+	writeUint16(0) // internal attrs
+	writeUint32(0) // external attrs
+	writeUint32(uint32(localHeaderOffset))
+	buf.WriteString(name)
+	centralDirSize := int64(buf.Len()) - centralDirStart
+
+	zip64EOCDOffset := int64(buf.Len())
+	writeUint32(zip64EOCDSignature)
+	var b8 [8]byte
+	writeUint64 := func(v uint64) { le.PutUint64(b8[:], v); buf.Write(b8[:]) }
+	writeUint64(uint64(zip64EOCDRecordSize - 12)) // size of remaining record
+	writeUint16(45)                               // version made by
+	writeUint16(45)                               // version needed
+	writeUint32(0)                                // disk number
+	writeUint32(0)                                // disk with start of central dir
+	writeUint64(1)                                // entries on this disk
+	writeUint64(1)                                // total entries
+	writeUint64(uint64(centralDirSize))
+	writeUint64(uint64(centralDirStart))
+
+	writeUint32(zip64LocatorSig)
+	writeUint32(0) // disk with zip64 EOCD
+	writeUint64(uint64(zip64EOCDOffset))
+	writeUint32(1) // total disks
+
+	writeUint32(eocdSignature)
+	writeUint16(0)          // disk number
+	writeUint16(0)          // disk with central dir
+	writeUint16(0xffff)     // entries on this disk (zip64 sentinel)
+	writeUint16(0xffff)     // total entries (zip64 sentinel)
+	writeUint32(0xffffffff) // central dir size (zip64 sentinel)
+	writeUint32(0xffffffff) // central dir offset (zip64 sentinel)
+	writeUint16(0)          // comment length
+
+	return buf.Bytes()
+}
+
+func TestZipFromTailZip64(t *testing.T) {
+	exe := []byte("fake executable")
+	data := append(append([]byte(nil), exe...), buildZip64(t)...)
+
+	zr, err := zipFromTail(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zipFromTail: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "z.txt" {
+		t.Fatalf("unexpected entries: %+v", zr.File)
+	}
+}
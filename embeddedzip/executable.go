@@ -0,0 +1,122 @@
+package embeddedzip
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"io"
+	"os"
+)
+
+// zipSectionName is the name OpenExecutable looks for when an embedded zip
+// has been placed in its own section rather than simply appended to the
+// file, e.g. by a linker flag or a tool that writes a proper ELF/Mach-O/PE
+// section instead of trailing bytes.
+const zipSectionName = "zipdata"
+
+// locateContainerZip inspects f as an ELF, Mach-O, or PE executable to find
+// where the embedded zip may live. If a section named zipSectionName exists,
+// its exact bounds are returned via sectionOffset/sectionSize/hasSection.
+// Otherwise, tailOffset is set to the end of the last section/segment known
+// to the container format, so that callers can search from there onward the
+// same way OpenEmbeddedZip searches from the end of the file. recognized is
+// false when f is not a container format this function understands, in which
+// case callers should fall back to treating the whole file as the search
+// region.
+func locateContainerZip(f *os.File) (sectionOffset, sectionSize, tailOffset int64, hasSection, recognized bool) {
+	if ef, err := elf.NewFile(f); err == nil {
+		recognized = true
+		for _, sect := range ef.Sections {
+			if end := int64(sect.Offset + sect.FileSize); end > tailOffset {
+				tailOffset = end
+			}
+			if sect.Name == zipSectionName {
+				sectionOffset, sectionSize, hasSection = int64(sect.Offset), int64(sect.FileSize), true
+			}
+		}
+		return
+	}
+	if mf, err := macho.NewFile(f); err == nil {
+		recognized = true
+		for _, sect := range mf.Sections {
+			if end := int64(sect.Offset) + int64(sect.Size); end > tailOffset {
+				tailOffset = end
+			}
+			if sect.Name == zipSectionName {
+				sectionOffset, sectionSize, hasSection = int64(sect.Offset), int64(sect.Size), true
+			}
+		}
+		return
+	}
+	if pf, err := pe.NewFile(f); err == nil {
+		recognized = true
+		for _, sect := range pf.Sections {
+			if end := int64(sect.Offset) + int64(sect.Size); end > tailOffset {
+				tailOffset = end
+			}
+			if sect.Name == zipSectionName {
+				sectionOffset, sectionSize, hasSection = int64(sect.Offset), int64(sect.Size), true
+			}
+		}
+		return
+	}
+	return
+}
+
+// OpenExecutable opens and returns the zip file embedded in the executable at
+// path. Unlike OpenEmbeddedZip, which assumes the zip is simply concatenated
+// after the executable bytes, OpenExecutable first tries to recognize the
+// executable as ELF, Mach-O, or PE and locate the zip data the way zipexe
+// does: in a section named "zipdata" if one exists, or otherwise by
+// searching from the end of the last known section onward. This copes with
+// platforms and toolchains that strip trailing bytes or otherwise disturb a
+// naive concatenation, such as codesigned Mach-O binaries on macOS or PE
+// files repacked by an installer. If the file is not a recognized executable
+// container, OpenExecutable falls back to OpenEmbeddedZip's "tail of file"
+// behavior. If no embedded zip file can be found, ErrNoFooter is returned.
+func OpenExecutable(path string) (*ZipReaderCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+		}
+	}()
+
+	length, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionOffset, sectionSize, tailOffset, hasSection, recognized := locateContainerZip(f)
+	if hasSection {
+		if zipReader, zerr := zipFromTail(io.NewSectionReader(f, sectionOffset, sectionSize), sectionSize); zerr == nil {
+			return &ZipReaderCloser{Reader: zipReader, Closer: f}, nil
+		}
+		// The named section didn't actually contain a valid zip; fall back
+		// to searching the rest of the file below.
+	}
+
+	searchOffset := int64(0)
+	if recognized {
+		searchOffset = tailOffset
+	}
+	searchLength := length - searchOffset
+	searchRA := io.NewSectionReader(f, searchOffset, searchLength)
+
+	searchLength, err = stripSelfCheckFooter(searchRA, searchLength)
+	if err != nil {
+		return nil, err
+	}
+	if _, zipLength, ok := stripIndex(searchRA, searchLength); ok {
+		searchLength = zipLength
+	}
+
+	zipReader, err := zipFromTail(searchRA, searchLength)
+	if err != nil {
+		return nil, err
+	}
+	return &ZipReaderCloser{Reader: zipReader, Closer: f}, nil
+}
@@ -0,0 +1,274 @@
+package embeddedzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTestZip returns an in-memory zip archive containing a single file
+// with the given name and content.
+func buildTestZip(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// openAndCheck opens path with OpenExecutable and asserts it yields exactly
+// one entry, name, with the given content.
+func openAndCheck(t *testing.T, path, name, content string) {
+	t.Helper()
+	zrc, err := OpenExecutable(path)
+	if err != nil {
+		t.Fatalf("OpenExecutable: %v", err)
+	}
+	defer zrc.Close()
+
+	f, err := zrc.Open(name)
+	if err != nil {
+		t.Fatalf("zip Open(%q): %v", name, err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Fatalf("%s contents = %q, want %q", name, data, content)
+	}
+}
+
+// writeExe writes data to a file under t.TempDir and returns its path.
+func writeExe(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fakebinary")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// buildELF returns a minimal, valid ELF64 executable whose only section
+// (besides the null section and .shstrtab needed to describe it) is named
+// sectionName and holds sectionData.
+func buildELF(t *testing.T, sectionName string, sectionData []byte) []byte {
+	t.Helper()
+
+	const headerSize = 64 // binary.Size(elf.Header64{})
+	dataOff := uint64(headerSize)
+
+	shstrtab := []byte{0} // index 0 is always the empty string
+	nameOff := uint32(len(shstrtab))
+	shstrtab = append(shstrtab, []byte(sectionName+"\x00")...)
+	shstrtabNameOff := uint32(len(shstrtab))
+	shstrtab = append(shstrtab, []byte(".shstrtab\x00")...)
+
+	shstrtabOff := dataOff + uint64(len(sectionData))
+	shoff := shstrtabOff + uint64(len(shstrtab))
+
+	var buf bytes.Buffer
+	hdr := elf.Header64{
+		Ident:     [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS64), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT)},
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shoff,
+		Shentsize: 64, // binary.Size(elf.Section64{})
+		Shnum:     3,  // null, sectionName, .shstrtab
+		Shstrndx:  2,
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(sectionData)
+	buf.Write(shstrtab)
+
+	sections := []elf.Section64{
+		{}, // SHT_NULL
+		{
+			Name: nameOff,
+			Type: uint32(elf.SHT_PROGBITS),
+			Off:  dataOff,
+			Size: uint64(len(sectionData)),
+		},
+		{
+			Name: shstrtabNameOff,
+			Type: uint32(elf.SHT_STRTAB),
+			Off:  shstrtabOff,
+			Size: uint64(len(shstrtab)),
+		},
+	}
+	for _, s := range sections {
+		if err := binary.Write(&buf, binary.LittleEndian, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestOpenExecutableELFTailSearch(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from elf tail")
+	exe := buildELF(t, ".data", []byte("some program data"))
+	path := writeExe(t, append(exe, zipData...))
+
+	openAndCheck(t, path, "hello.txt", "hello from elf tail")
+}
+
+func TestOpenExecutableELFZipSection(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from elf section")
+	exe := buildELF(t, zipSectionName, zipData)
+
+	path := writeExe(t, exe)
+	openAndCheck(t, path, "hello.txt", "hello from elf section")
+}
+
+// buildMachO returns a minimal, valid 64-bit Mach-O executable with a single
+// __TEXT segment containing one section named sectionName holding
+// sectionData. macho.NewFile reads a 32-byte file header (28 documented
+// fields plus 4 bytes of reserved padding not represented in
+// macho.FileHeader), so that padding is written explicitly here.
+func buildMachO(t *testing.T, sectionName string, sectionData []byte) []byte {
+	t.Helper()
+
+	const fileHeaderSize = 32 // 28-byte FileHeader + 4 bytes reserved, see macho.NewFile
+	const segCmdSize = 72     // binary.Size(macho.Segment64{})
+	const sectSize = 80       // binary.Size(macho.Section64{})
+
+	dataOff := uint32(fileHeaderSize + segCmdSize + sectSize)
+
+	hdr := macho.FileHeader{
+		Magic: macho.Magic64,
+		Cpu:   macho.CpuAmd64,
+		Type:  macho.TypeExec,
+		Ncmd:  1,
+		Cmdsz: uint32(segCmdSize + sectSize),
+	}
+
+	var segName, sectName [16]byte
+	copy(segName[:], "__TEXT")
+	copy(sectName[:], sectionName)
+
+	seg := macho.Segment64{
+		Cmd:    macho.LoadCmdSegment64,
+		Len:    uint32(segCmdSize + sectSize),
+		Name:   segName,
+		Offset: uint64(dataOff),
+		Filesz: uint64(len(sectionData)),
+		Nsect:  1,
+	}
+	sect := macho.Section64{
+		Name:   sectName,
+		Seg:    segName,
+		Offset: dataOff,
+		Size:   uint64(len(sectionData)),
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(make([]byte, 4)) // reserved padding macho.NewFile skips past
+	if err := binary.Write(&buf, binary.LittleEndian, seg); err != nil {
+		t.Fatal(err)
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, sect); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(sectionData)
+	return buf.Bytes()
+}
+
+func TestOpenExecutableMachOTailSearch(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from macho tail")
+	exe := buildMachO(t, "__text", []byte("some program data"))
+	path := writeExe(t, append(exe, zipData...))
+
+	openAndCheck(t, path, "hello.txt", "hello from macho tail")
+}
+
+func TestOpenExecutableMachOZipSection(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from macho section")
+	exe := buildMachO(t, zipSectionName, zipData)
+
+	path := writeExe(t, exe)
+	openAndCheck(t, path, "hello.txt", "hello from macho section")
+}
+
+// buildPE returns a minimal, valid PE/COFF executable, omitting the MZ/DOS
+// stub entirely (pe.NewFile supports that, reading the file header directly
+// at offset 0) and setting SizeOfOptionalHeader to 0 so pe.NewFile skips the
+// optional header. Its only section is named sectionName and holds
+// sectionData.
+func buildPE(t *testing.T, sectionName string, sectionData []byte) []byte {
+	t.Helper()
+
+	const fileHeaderSize = 20 // binary.Size(pe.FileHeader{})
+	const sectHeaderSize = 40 // binary.Size(pe.SectionHeader32{})
+	dataOff := uint32(fileHeaderSize + sectHeaderSize)
+
+	hdr := pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_AMD64,
+		NumberOfSections:     1,
+		SizeOfOptionalHeader: 0,
+	}
+
+	var name [8]byte
+	copy(name[:], sectionName)
+	sect := pe.SectionHeader32{
+		Name:             name,
+		VirtualSize:      uint32(len(sectionData)),
+		SizeOfRawData:    uint32(len(sectionData)),
+		PointerToRawData: dataOff,
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, sect); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(sectionData)
+	return buf.Bytes()
+}
+
+func TestOpenExecutablePETailSearch(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from pe tail")
+	exe := buildPE(t, ".text", []byte("some program data"))
+	path := writeExe(t, append(exe, zipData...))
+
+	openAndCheck(t, path, "hello.txt", "hello from pe tail")
+}
+
+func TestOpenExecutablePEZipSection(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from pe section")
+	exe := buildPE(t, zipSectionName, zipData)
+
+	path := writeExe(t, exe)
+	openAndCheck(t, path, "hello.txt", "hello from pe section")
+}
+
+func TestOpenExecutableUnrecognizedFallsBackToTail(t *testing.T) {
+	zipData := buildTestZip(t, "hello.txt", "hello from fallback")
+	path := writeExe(t, append([]byte("#!/bin/fake-executable\n"), zipData...))
+
+	openAndCheck(t, path, "hello.txt", "hello from fallback")
+}
@@ -0,0 +1,111 @@
+package embeddedzip
+
+import (
+	"io"
+	"os"
+
+	"github.com/interarticle/goembed/embeddedzip/index"
+)
+
+// stripIndex inspects the tail of a file of the given length, read through
+// ra, for an index footer written by embeddedzip/appender (see
+// embeddedzip/index). If one is found, it returns the index and length
+// truncated to just the zip bytes preceding it, so that a subsequent
+// end-of-central-directory scan over that truncated length lands on the
+// zip's own footer instead of the index's. ok is false, with length
+// returned unchanged, if no index is present.
+func stripIndex(ra io.ReaderAt, length int64) (idx *index.Index, zipLength int64, ok bool) {
+	ix, err := index.Open(ra, length)
+	if err != nil {
+		return nil, length, false
+	}
+	start, zlen := ix.ZipBounds()
+	return ix, start + zlen, true
+}
+
+// OpenEmbeddedFile returns a reader for the decompressed content of the
+// single named file in the zip embedded in the current executable, the way
+// LoadEmbeddedArguments wants "arguments.txt". Unlike OpenEmbeddedZip, which
+// parses the whole zip's central directory before anything can be read,
+// OpenEmbeddedFile uses a precomputed index appended by embeddedzip/appender
+// (see embeddedzip/index), when present, to seek directly to name's local
+// file header, touching no other entry's metadata. It returns ErrFileNotFound
+// if no entry with that name exists.
+//
+// If the executable has no index -- e.g. it predates appender writing one,
+// or the index can't serve name itself, such as a WinZip AES-encrypted entry,
+// which the index format doesn't carry enough information to decrypt --
+// OpenEmbeddedFile transparently falls back to the full OpenEmbeddedZip
+// parse. Use OpenEmbeddedFileWithPassword to supply a decryption password for
+// that fallback.
+func OpenEmbeddedFile(name string) (io.ReadCloser, error) {
+	return OpenEmbeddedFileWithPassword(name, "")
+}
+
+// OpenEmbeddedFileWithPassword is like OpenEmbeddedFile, but supplies pw as
+// the decryption password if name turns out to be WinZip AES-encrypted and
+// the index fast path has to fall back to the full parse to serve it.
+func OpenEmbeddedFileWithPassword(name, password string) (io.ReadCloser, error) {
+	f, err := os.Open(os.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	closeOnErr := true
+	defer func() {
+		if closeOnErr {
+			f.Close()
+		}
+	}()
+
+	length, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	length, err = stripSelfCheckFooter(f, length)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx, zipLength, ok := stripIndex(f, length); ok {
+		if r, ierr := idx.Open(name); ierr == nil {
+			closeOnErr = false
+			return &readCloserWithFile{ReadCloser: r, f: f}, nil
+		}
+		// The index either doesn't have this entry or can't serve it (e.g.
+		// it needs AES decryption, which the index format doesn't carry
+		// enough information for) -- fall back to the full parse below,
+		// which can. Either way, the index footer narrows the search to
+		// just the zip's own bytes, the same as stripSelfCheckFooter does
+		// for its trailer.
+		length = zipLength
+	}
+
+	zipReader, err := zipFromTail(f, length)
+	if err != nil {
+		return nil, err
+	}
+	zrc := &ZipReaderCloser{Reader: zipReader, Closer: f, password: password}
+	r, err := zrc.OpenDecrypted(name)
+	if err != nil {
+		zrc.Close()
+		return nil, err
+	}
+	closeOnErr = false
+	return &readCloserWithFile{ReadCloser: r, f: zrc}, nil
+}
+
+// readCloserWithFile closes f after the wrapped ReadCloser, so the
+// underlying executable file (or ZipReaderCloser) stays open for as long as
+// its content is being read.
+type readCloserWithFile struct {
+	io.ReadCloser
+	f io.Closer
+}
+
+func (rc *readCloserWithFile) Close() error {
+	err := rc.ReadCloser.Close()
+	if ferr := rc.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+package embeddedzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/interarticle/goembed/embeddedzip/index"
+)
+
+// buildExeWithIndex returns exe-like bytes: exePrefix, followed by a zip of
+// files, a precomputed index of it, and a self-check trailer -- exactly the
+// layout embeddedzip/appender.Append now writes.
+func buildExeWithIndex(t *testing.T, exePrefix []byte, files map[string]string) []byte {
+	t.Helper()
+	return appendIndexedZip(t, exePrefix, buildTestZipMulti(t, files))
+}
+
+// buildTestZipMulti is buildTestZip for more than one file.
+func buildTestZipMulti(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// appendIndexedZip appends a precomputed index of zipData and a self-check
+// trailer covering both to exePrefix, the way embeddedzip/appender.Append
+// does.
+func appendIndexedZip(t *testing.T, exePrefix, zipData []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := index.EntriesFromZip(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idxBuf bytes.Buffer
+	if _, err := index.Write(&idxBuf, int64(len(zipData)), entries); err != nil {
+		t.Fatal(err)
+	}
+
+	h := sha256.New()
+	h.Write(zipData)
+	h.Write(idxBuf.Bytes())
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+
+	out := append([]byte(nil), exePrefix...)
+	out = append(out, zipData...)
+	out = append(out, idxBuf.Bytes()...)
+
+	var footerBuf bytes.Buffer
+	if err := WriteSelfCheckFooter(&footerBuf, int64(len(zipData)+idxBuf.Len()), sum); err != nil {
+		t.Fatal(err)
+	}
+	return append(out, footerBuf.Bytes()...)
+}
+
+// withArgs0 temporarily points os.Args[0] at path for the duration of the
+// test; OpenEmbeddedFile and OpenEmbeddedZip both read os.Args[0] to find
+// the current executable.
+func withArgs0(t *testing.T, path string) {
+	t.Helper()
+	orig := os.Args[0]
+	os.Args[0] = path
+	t.Cleanup(func() { os.Args[0] = orig })
+}
+
+func TestOpenEmbeddedFileUsesIndex(t *testing.T) {
+	data := buildExeWithIndex(t, []byte("#!/bin/fake-executable\n"), map[string]string{
+		"hello.txt": "hello from index",
+		"dir/b.txt": "other file",
+	})
+	path := filepath.Join(t.TempDir(), "exe")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	withArgs0(t, path)
+
+	r, err := OpenEmbeddedFile("hello.txt")
+	if err != nil {
+		t.Fatalf("OpenEmbeddedFile: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello from index" {
+		t.Fatalf("got %q, want %q", got, "hello from index")
+	}
+
+	// The index sits between the zip and the self-check trailer; confirm
+	// the ordinary full parse still sees every entry, not just the index.
+	zrc, err := OpenEmbeddedZip()
+	if err != nil {
+		t.Fatalf("OpenEmbeddedZip: %v", err)
+	}
+	defer zrc.Close()
+	if len(zrc.File) != 2 {
+		t.Fatalf("OpenEmbeddedZip saw %d entries, want 2", len(zrc.File))
+	}
+}
+
+func TestOpenEmbeddedFileMissingName(t *testing.T) {
+	data := buildExeWithIndex(t, []byte("#!/bin/fake-executable\n"), map[string]string{"hello.txt": "hi"})
+	path := filepath.Join(t.TempDir(), "exe")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	withArgs0(t, path)
+
+	if _, err := OpenEmbeddedFile("missing.txt"); err != ErrFileNotFound {
+		t.Fatalf("got %v, want ErrFileNotFound", err)
+	}
+}
+
+func TestOpenEmbeddedFileNoIndexFallsBack(t *testing.T) {
+	// A plain embed with no index appended at all -- e.g. one written before
+	// appender wrote one -- must still work via the full-parse fallback.
+	zipData := buildTestZip(t, "hello.txt", "hello from fallback")
+	path := filepath.Join(t.TempDir(), "exe")
+	data := append([]byte("#!/bin/fake-executable\n"), zipData...)
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	withArgs0(t, path)
+
+	r, err := OpenEmbeddedFile("hello.txt")
+	if err != nil {
+		t.Fatalf("OpenEmbeddedFile: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello from fallback" {
+		t.Fatalf("got %q, want %q", got, "hello from fallback")
+	}
+}
+
+func TestOpenEmbeddedFileWithPasswordFallsBackForAES(t *testing.T) {
+	// The index records a WinZip AES entry's Method as 99, which
+	// index.OpenEntry doesn't know how to decompress; OpenEmbeddedFile must
+	// fall back to the full, password-aware parse to actually decrypt it.
+	const password = "hunter2"
+	zipData := buildAESZip(t, "secret.txt", password, []byte("the secret payload"))
+	data := appendIndexedZip(t, []byte("#!/bin/fake-executable\n"), zipData)
+	path := filepath.Join(t.TempDir(), "exe")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	withArgs0(t, path)
+
+	r, err := OpenEmbeddedFileWithPassword("secret.txt", password)
+	if err != nil {
+		t.Fatalf("OpenEmbeddedFileWithPassword: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "the secret payload" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package index
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// EntriesFromZip builds the Entry slice Write needs from an already-parsed
+// zip archive, deriving each Entry's LocalHeaderOffset (relative to the
+// start of the zip, as Write and Open expect) from the name and extra field
+// lengths that precede its data in the local file header.
+func EntriesFromZip(zr *zip.Reader) ([]Entry, error) {
+	entries := make([]Entry, len(zr.File))
+	for i, f := range zr.File {
+		dataOffset, err := f.DataOffset()
+		if err != nil {
+			return nil, fmt.Errorf("index: reading data offset for %q: %w", f.Name, err)
+		}
+		entries[i] = Entry{
+			Name:              f.Name,
+			Method:            f.Method,
+			CRC32:             f.CRC32,
+			CompressedSize:    int64(f.CompressedSize64),
+			UncompressedSize:  int64(f.UncompressedSize64),
+			LocalHeaderOffset: dataOffset - int64(localHeaderSize) - int64(len(f.Name)) - int64(len(f.Extra)),
+		}
+	}
+	return entries, nil
+}
+
+// Write serializes entries, sorted by Name, as a precomputed index covering
+// a zip of zipLength bytes, and writes them to w, followed by the
+// fixed-size footer Open looks for at the end of the file. It returns the
+// number of bytes written. entries need not already be sorted, but their
+// names must be unique. Write is meant to be called by a build-time tool
+// right after appending a zip of zipLength bytes to an executable, with w
+// positioned immediately after the zip's own end of central directory
+// record.
+func Write(w io.Writer, zipLength int64, entries []Entry) (int64, error) {
+	sorted := append([]Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	offsets := make([]uint32, len(sorted))
+	for i, e := range sorted {
+		if i > 0 && sorted[i].Name == sorted[i-1].Name {
+			return 0, fmt.Errorf("index: duplicate entry name %q", e.Name)
+		}
+		offsets[i] = uint32(cw.n)
+		if err := writeEntry(cw, e); err != nil {
+			return 0, err
+		}
+	}
+	entriesLength := cw.n
+
+	for _, off := range offsets {
+		if err := binary.Write(cw, binary.LittleEndian, off); err != nil {
+			return 0, err
+		}
+	}
+
+	ft := footer{
+		Magic:         magic,
+		ZipLength:     uint64(zipLength),
+		EntriesLength: uint64(entriesLength),
+		Count:         uint32(len(sorted)),
+	}
+	if err := binary.Write(cw, binary.LittleEndian, ft); err != nil {
+		return 0, err
+	}
+
+	if err := cw.flush(); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+// countingWriter wraps a *bufio.Writer, tracking how many bytes have been
+// written so Write can record each entry's starting offset.
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func (cw *countingWriter) flush() error {
+	return cw.w.Flush()
+}
+
+func writeEntry(w io.Writer, e Entry) error {
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) error {
+		n := binary.PutUvarint(buf[:], v)
+		_, err := w.Write(buf[:n])
+		return err
+	}
+
+	if err := putUvarint(uint64(len(e.Name))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, e.Name); err != nil {
+		return err
+	}
+	for _, v := range [...]uint64{
+		uint64(e.Method),
+		uint64(e.CRC32),
+		uint64(e.CompressedSize),
+		uint64(e.UncompressedSize),
+		uint64(e.LocalHeaderOffset),
+	} {
+		if err := putUvarint(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
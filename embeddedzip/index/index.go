@@ -0,0 +1,215 @@
+// Package index implements a compact, precomputed directory for the files
+// in a large embedded zip, appended alongside it with its own magic footer.
+// It lets a program that only needs a handful of named assets skip
+// archive/zip's O(N) central directory parse on every process start: Open
+// reads a small, fixed-size footer, and Lookup binary-searches a sorted name
+// table to go straight to a file's local file header, touching neither the
+// zip's central directory nor any entry but the one requested.
+//
+// An index is optional. Callers should treat ErrNoIndex from Open as a
+// signal to fall back to parsing the zip normally with archive/zip, the way
+// embeddedzip.OpenEmbeddedZip does when no index has been appended.
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrNoIndex is returned by Open when the file has no index footer.
+	ErrNoIndex = errors.New("index: no precomputed index footer found")
+	// ErrNotFound is returned by Lookup and Open when no entry exists with
+	// the requested name.
+	ErrNotFound = errors.New("index: no entry with that name")
+)
+
+const (
+	magic = 0x676f656d62696478 // arbitrary 8-byte signature ("goembidx")
+
+	localHeaderSignature = 0x04034b50
+	localHeaderSize      = 30
+)
+
+// Entry describes one file recorded in an Index.
+type Entry struct {
+	Name              string
+	Method            uint16
+	CRC32             uint32
+	CompressedSize    int64
+	UncompressedSize  int64
+	LocalHeaderOffset int64 // offset of the entry's local file header, relative to the start of the zip file
+}
+
+// footer is the fixed-size record Open looks for at the very end of the
+// file, letting it locate the rest of the index, and the zip it describes,
+// without parsing anything else first.
+type footer struct {
+	Magic         uint64
+	ZipLength     uint64
+	EntriesLength uint64
+	Count         uint32
+}
+
+var footerSize = binary.Size(footer{})
+
+// Index is a read-only, precomputed directory for a zip's entries.
+type Index struct {
+	ra            io.ReaderAt
+	zipStart      int64
+	zipLength     int64
+	entriesOffset int64
+	tableOffset   int64
+	count         int
+}
+
+// Open parses the index footer at the end of a file of the given total
+// length, read through ra. It returns ErrNoIndex if no footer is present.
+// Unlike the zip's own end of central directory record, the footer records
+// the zip's length directly, so Open never has to scan for it.
+func Open(ra io.ReaderAt, totalLength int64) (*Index, error) {
+	if totalLength < int64(footerSize) {
+		return nil, ErrNoIndex
+	}
+	buf := make([]byte, footerSize)
+	if _, err := ra.ReadAt(buf, totalLength-int64(footerSize)); err != nil {
+		return nil, err
+	}
+	var ft footer
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &ft); err != nil {
+		return nil, err
+	}
+	if ft.Magic != magic {
+		return nil, ErrNoIndex
+	}
+
+	tableOffset := totalLength - int64(footerSize) - int64(ft.Count)*4
+	entriesOffset := tableOffset - int64(ft.EntriesLength)
+	zipStart := entriesOffset - int64(ft.ZipLength)
+	if zipStart < 0 || tableOffset < entriesOffset {
+		return nil, ErrNoIndex
+	}
+	return &Index{
+		ra:            ra,
+		zipStart:      zipStart,
+		zipLength:     int64(ft.ZipLength),
+		entriesOffset: entriesOffset,
+		tableOffset:   tableOffset,
+		count:         int(ft.Count),
+	}, nil
+}
+
+// ZipBounds returns the start offset and length, within the file Open was
+// called on, of the zip archive the index describes. Callers that need to
+// parse the zip itself (e.g. to list every entry, rather than look up one by
+// name) use this to bound that parse to exactly the zip's own bytes,
+// excluding the index appended after it.
+func (idx *Index) ZipBounds() (start, length int64) {
+	return idx.zipStart, idx.zipLength
+}
+
+// Lookup returns the Entry for name, binary-searching the precomputed
+// sorted name table instead of walking every entry in the zip's central
+// directory.
+func (idx *Index) Lookup(name string) (Entry, error) {
+	lo, hi := 0, idx.count
+	for lo < hi {
+		mid := (lo + hi) / 2
+		entry, err := idx.entryAt(mid)
+		if err != nil {
+			return Entry{}, err
+		}
+		switch {
+		case entry.Name == name:
+			return entry, nil
+		case entry.Name < name:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+func (idx *Index) entryAt(i int) (Entry, error) {
+	var offBuf [4]byte
+	if _, err := idx.ra.ReadAt(offBuf[:], idx.tableOffset+int64(i)*4); err != nil {
+		return Entry{}, err
+	}
+	relOffset := int64(binary.LittleEndian.Uint32(offBuf[:]))
+	return decodeEntryAt(idx.ra, idx.entriesOffset+relOffset, idx.tableOffset)
+}
+
+// decodeEntryAt decodes the varint-encoded entry starting at offset, never
+// reading past end (the start of the offset table).
+func decodeEntryAt(ra io.ReaderAt, offset, end int64) (Entry, error) {
+	r := bufio.NewReader(io.NewSectionReader(ra, offset, end-offset))
+
+	nameLen, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Entry{}, err
+	}
+	nameBuf := make([]byte, nameLen)
+	if _, err := io.ReadFull(r, nameBuf); err != nil {
+		return Entry{}, err
+	}
+
+	fields := make([]uint64, 5)
+	for i := range fields {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return Entry{}, err
+		}
+		fields[i] = v
+	}
+
+	return Entry{
+		Name:              string(nameBuf),
+		Method:            uint16(fields[0]),
+		CRC32:             uint32(fields[1]),
+		CompressedSize:    int64(fields[2]),
+		UncompressedSize:  int64(fields[3]),
+		LocalHeaderOffset: int64(fields[4]),
+	}, nil
+}
+
+// Open returns a reader for the decompressed content of the named entry. It
+// is Lookup followed by OpenEntry, for callers who only want the data.
+func (idx *Index) Open(name string) (io.ReadCloser, error) {
+	e, err := idx.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return idx.OpenEntry(e)
+}
+
+// OpenEntry returns a reader for the decompressed content of e, which must
+// have come from idx.Lookup. It reads and validates only e's local file
+// header before decompressing; it never parses the zip's central directory.
+func (idx *Index) OpenEntry(e Entry) (io.ReadCloser, error) {
+	hdr := make([]byte, localHeaderSize)
+	if _, err := idx.ra.ReadAt(hdr, idx.zipStart+e.LocalHeaderOffset); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(hdr) != localHeaderSignature {
+		return nil, fmt.Errorf("index: local file header for %q is corrupt or the index is stale", e.Name)
+	}
+	nameLen := binary.LittleEndian.Uint16(hdr[26:28])
+	extraLen := binary.LittleEndian.Uint16(hdr[28:30])
+	dataOffset := idx.zipStart + e.LocalHeaderOffset + localHeaderSize + int64(nameLen) + int64(extraLen)
+
+	raw := io.NewSectionReader(idx.ra, dataOffset, e.CompressedSize)
+	switch e.Method {
+	case 0: // store
+		return io.NopCloser(raw), nil
+	case 8: // deflate
+		return flate.NewReader(raw), nil
+	default:
+		return nil, fmt.Errorf("index: entry %q uses unsupported compression method %d", e.Name, e.Method)
+	}
+}
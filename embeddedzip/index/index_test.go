@@ -0,0 +1,119 @@
+package index
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+// buildZipWithIndex writes a zip archive for the given name->content files,
+// followed immediately by a precomputed index covering them, exactly as
+// Write's doc comment describes an intended caller using it: positioned
+// right after the zip's own end of central directory record.
+func buildZipWithIndex(t *testing.T, files map[string]string) (data []byte, zipLength int64) {
+	t.Helper()
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := EntriesFromZip(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := append([]byte(nil), zbuf.Bytes()...)
+	var idxBuf bytes.Buffer
+	if _, err := Write(&idxBuf, int64(zbuf.Len()), entries); err != nil {
+		t.Fatal(err)
+	}
+	return append(out, idxBuf.Bytes()...), int64(zbuf.Len())
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"hello.txt": "hello a",
+		"dir/b.txt": "hello b, a bit longer",
+	}
+	data, zipLength := buildZipWithIndex(t, files)
+
+	idx, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if start, length := idx.ZipBounds(); start != 0 || length != zipLength {
+		t.Fatalf("ZipBounds() = (%d, %d), want (0, %d)", start, length, zipLength)
+	}
+
+	for name, content := range files {
+		e, err := idx.Lookup(name)
+		if err != nil {
+			t.Fatalf("Lookup(%q): %v", name, err)
+		}
+		if e.UncompressedSize != int64(len(content)) {
+			t.Fatalf("Lookup(%q).UncompressedSize = %d, want %d", name, e.UncompressedSize, len(content))
+		}
+
+		r, err := idx.OpenEntry(e)
+		if err != nil {
+			t.Fatalf("OpenEntry(%q): %v", name, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Fatalf("OpenEntry(%q) = %q, want %q", name, got, content)
+		}
+
+		r2, err := idx.Open(name)
+		if err != nil {
+			t.Fatalf("idx.Open(%q): %v", name, err)
+		}
+		got2, err := io.ReadAll(r2)
+		r2.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got2) != content {
+			t.Fatalf("idx.Open(%q) = %q, want %q", name, got2, content)
+		}
+	}
+
+	if _, err := idx.Lookup("missing.txt"); err != ErrNotFound {
+		t.Fatalf("Lookup(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenNoIndex(t *testing.T) {
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	w, err := zw.Create("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("a"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len())); err != ErrNoIndex {
+		t.Fatalf("Open on a zip with no index = %v, want ErrNoIndex", err)
+	}
+}
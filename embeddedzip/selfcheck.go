@@ -0,0 +1,80 @@
+package embeddedzip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrSelfCheckFailed is returned when a self-check trailer written by
+// embeddedzip/appender is present but its recorded SHA-256 does not match
+// the zip bytes it covers, meaning the embed was corrupted or truncated
+// after being appended.
+var ErrSelfCheckFailed = errors.New("embeddedzip: self-check trailer present but does not match the embedded zip's contents")
+
+// selfCheckMagic identifies a trailer written by embeddedzip/appender,
+// distinguishing it from a bare embedded zip with no such trailer.
+const selfCheckMagic = 0x676f656d63686b31 // "goemchk1" read as a big-endian uint64
+
+type selfCheckFooter struct {
+	Magic     uint64
+	ZipLength uint64
+	SHA256    [32]byte
+}
+
+var selfCheckFooterSize = binary.Size(selfCheckFooter{})
+
+// WriteSelfCheckFooter appends a self-check trailer to w covering the
+// zipLength bytes of embedded data (the zip, plus any index appended after
+// it) that were just written ahead of it on the same stream, recording
+// their SHA-256 (sum) so a later OpenEmbeddedZip or
+// OpenExecutable call can detect truncation or corruption of the embed.
+func WriteSelfCheckFooter(w io.Writer, zipLength int64, sum [32]byte) error {
+	return binary.Write(w, binary.LittleEndian, selfCheckFooter{
+		Magic:     selfCheckMagic,
+		ZipLength: uint64(zipLength),
+		SHA256:    sum,
+	})
+}
+
+// stripSelfCheckFooter inspects the tail of a file of the given length,
+// read through ra, for a self-check trailer. If one is found, its recorded
+// SHA-256 is verified against the zip bytes it claims to cover; a mismatch
+// returns ErrSelfCheckFailed. The returned length excludes the trailer, for
+// the EOCD scan to search within; it is the input length unchanged if no
+// trailer is present.
+func stripSelfCheckFooter(ra io.ReaderAt, length int64) (int64, error) {
+	if length < int64(selfCheckFooterSize) {
+		return length, nil
+	}
+	buf := make([]byte, selfCheckFooterSize)
+	if _, err := ra.ReadAt(buf, length-int64(selfCheckFooterSize)); err != nil {
+		return 0, err
+	}
+	var ft selfCheckFooter
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &ft); err != nil {
+		return 0, err
+	}
+	if ft.Magic != selfCheckMagic {
+		return length, nil
+	}
+
+	trailerStart := length - int64(selfCheckFooterSize)
+	zipLength := int64(ft.ZipLength)
+	if zipLength < 0 || zipLength > trailerStart {
+		return 0, ErrSelfCheckFailed
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(ra, trailerStart-zipLength, zipLength)); err != nil {
+		return 0, err
+	}
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	if sum != ft.SHA256 {
+		return 0, ErrSelfCheckFailed
+	}
+	return trailerStart, nil
+}